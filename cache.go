@@ -0,0 +1,134 @@
+package chessimage
+
+import (
+	"container/list"
+	"image"
+	"sync"
+)
+
+const defaultPieceCacheCapacity = 256
+
+// pieceCacheKey identifies a decoded-and-resized piece image. fsID identifies
+// an explicit Options.FileSystem override (empty when none is set) so that a
+// custom filesystem with a blank Theme/AssetPath never collides with the
+// identically-blank-keyed default "cburnett" theme.
+type pieceCacheKey struct {
+	theme     string
+	fsID      string
+	assetPath string
+	symbol    string
+	pieceSize int
+}
+
+type pieceCacheEntry struct {
+	key   pieceCacheKey
+	image *image.RGBA
+}
+
+// pieceCache is a small LRU cache for decoded piece images, avoiding the cost
+// of re-decoding and re-scaling a PNG for every piece on every render.
+type pieceCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[pieceCacheKey]*list.Element
+	order    *list.List
+}
+
+func newPieceCache(capacity int) *pieceCache {
+	return &pieceCache{
+		capacity: capacity,
+		items:    make(map[pieceCacheKey]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *pieceCache) get(key pieceCacheKey) (*image.RGBA, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*pieceCacheEntry).image, true
+}
+
+func (c *pieceCache) put(key pieceCacheKey, img *image.RGBA) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.order.MoveToFront(elem)
+		elem.Value.(*pieceCacheEntry).image = img
+		return
+	}
+	elem := c.order.PushFront(&pieceCacheEntry{key: key, image: img})
+	c.items[key] = elem
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		if oldest != nil {
+			c.order.Remove(oldest)
+			delete(c.items, oldest.Value.(*pieceCacheEntry).key)
+		}
+	}
+}
+
+var globalPieceCache = newPieceCache(defaultPieceCacheCapacity)
+
+const defaultTileCacheCapacity = 1024
+
+type tileCacheEntry struct {
+	key   string
+	image *image.RGBA
+}
+
+// tileCache is a small LRU cache for fully composed board-square tiles
+// (background + highlight/check/marker + piece), keyed by a content hash of
+// everything that affects their appearance. This lets successive renders of
+// similar positions (e.g. consecutive frames of a game) skip recomposing
+// tiles that look identical to one already rendered.
+type tileCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+}
+
+func newTileCache(capacity int) *tileCache {
+	return &tileCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *tileCache) get(key string) (*image.RGBA, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*tileCacheEntry).image, true
+}
+
+func (c *tileCache) put(key string, img *image.RGBA) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.order.MoveToFront(elem)
+		elem.Value.(*tileCacheEntry).image = img
+		return
+	}
+	elem := c.order.PushFront(&tileCacheEntry{key: key, image: img})
+	c.items[key] = elem
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		if oldest != nil {
+			c.order.Remove(oldest)
+			delete(c.items, oldest.Value.(*tileCacheEntry).key)
+		}
+	}
+}
+
+var globalTileCache = newTileCache(defaultTileCacheCapacity)
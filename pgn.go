@@ -0,0 +1,446 @@
+package chessimage
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	pgnTagPairRe   = regexp.MustCompile(`(?m)^\[.*\]\s*$`)
+	pgnCommentRe   = regexp.MustCompile(`\{[^}]*\}`)
+	pgnVariationRe = regexp.MustCompile(`\([^()]*\)`)
+	pgnNAGRe       = regexp.MustCompile(`\$\d+`)
+	pgnMoveNumRe   = regexp.MustCompile(`\d+\.(\.\.)?`)
+	pgnResultRe    = regexp.MustCompile(`1-0|0-1|1/2-1/2|\*`)
+)
+
+// Game is the sequence of positions produced by replaying a PGN's move text
+// from the standard starting position, one entry per ply.
+type Game struct {
+	positions []*Position
+	lastMoves []LastMove
+	checks    []Tile
+}
+
+// NewRendererFromPGN parses pgn's move text, replays it move by move from the
+// standard starting position, and prepares a renderer positioned at the final
+// move of the game. Use RenderGIF to render every position as an animated GIF.
+func NewRendererFromPGN(pgn string) (*Renderer, error) {
+	game, err := parsePGN(pgn)
+	if err != nil {
+		return nil, err
+	}
+	if len(game.positions) == 0 {
+		return nil, fmt.Errorf("chessimage: pgn contains no moves")
+	}
+
+	r := &Renderer{}
+	r.loadFrameFrom(game, len(game.positions)-1)
+	return r, nil
+}
+
+// RenderGIF plays through the parsed game and writes an animated GIF to w,
+// with one frame per position and frameDelay hundredths of a second between
+// frames. The renderer must have been created with NewRendererFromPGN.
+func (r *Renderer) RenderGIF(w io.Writer, opts Options, frameDelay int) error {
+	if r.game == nil {
+		return fmt.Errorf("chessimage: RenderGIF requires a renderer created with NewRendererFromPGN")
+	}
+
+	anim := gif.GIF{}
+	frame := &Renderer{}
+	for i := range r.game.positions {
+		frame.loadFrameFrom(r.game, i)
+
+		img, err := frame.Render(opts)
+		if err != nil {
+			return err
+		}
+
+		paletted := image.NewPaletted(img.Bounds(), palette.Plan9)
+		draw.Draw(paletted, img.Bounds(), img, image.Point{}, draw.Src)
+
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, frameDelay)
+	}
+
+	return gif.EncodeAll(w, &anim)
+}
+
+// loadFrameFrom positions r at the i-th ply of game.
+func (r *Renderer) loadFrameFrom(game *Game, i int) {
+	r.position = game.positions[i]
+	r.checkTile = game.checks[i]
+	r.game = game
+	if game.lastMoves[i] != (LastMove{}) {
+		lastMove := game.lastMoves[i]
+		r.lastMove = &lastMove
+	} else {
+		r.lastMove = nil
+	}
+}
+
+func tokenizePGN(pgn string) []string {
+	s := pgnTagPairRe.ReplaceAllString(pgn, "")
+	s = pgnCommentRe.ReplaceAllString(s, "")
+	for pgnVariationRe.MatchString(s) {
+		s = pgnVariationRe.ReplaceAllString(s, "")
+	}
+	s = pgnNAGRe.ReplaceAllString(s, "")
+	s = pgnMoveNumRe.ReplaceAllString(s, "")
+	s = pgnResultRe.ReplaceAllString(s, "")
+	return strings.Fields(s)
+}
+
+func parsePGN(pgn string) (*Game, error) {
+	game := &Game{}
+	current := startingBoard()
+	whiteToMove := true
+	for _, san := range tokenizePGN(pgn) {
+		next, lastMove, checkTile, err := applySAN(current, san, whiteToMove)
+		if err != nil {
+			return nil, fmt.Errorf("chessimage: parsing move %q: %w", san, err)
+		}
+		game.positions = append(game.positions, next)
+		game.lastMoves = append(game.lastMoves, lastMove)
+		game.checks = append(game.checks, checkTile)
+		current = next
+		whiteToMove = !whiteToMove
+	}
+	return game, nil
+}
+
+func startingBoard() *Position {
+	backRank := [8]string{"r", "n", "b", "q", "k", "b", "n", "r"}
+	p := &Position{}
+	for file := 0; file < 8; file++ {
+		p.setPiece(Tile(file), backRank[file])
+		p.setPiece(Tile(8+file), "p")
+		p.setPiece(Tile(48+file), "P")
+		p.setPiece(Tile(56+file), strings.ToUpper(backRank[file]))
+	}
+	return p
+}
+
+// applySAN replays a single SAN move against pos and returns the resulting
+// position, the from/to tiles for highlighting, and the tile of a king left
+// in check (NoTile if none), as indicated by SAN's own "+"/"#" suffix.
+func applySAN(pos *Position, san string, whiteToMove bool) (*Position, LastMove, Tile, error) {
+	next := pos.clone()
+	move := strings.TrimRight(san, "+#!?")
+
+	if move == "O-O" || move == "O-O-O" || move == "0-0" || move == "0-0-0" {
+		lastMove, err := applyCastle(next, move, whiteToMove)
+		if err != nil {
+			return nil, LastMove{}, NoTile, err
+		}
+		return next, lastMove, checkTileFor(next, san, whiteToMove), nil
+	}
+
+	symbol, rest := pieceSymbolFor(move, whiteToMove)
+
+	promotion := ""
+	if idx := strings.IndexByte(rest, '='); idx >= 0 {
+		promotion = rest[idx+1 : idx+2]
+		if !whiteToMove {
+			promotion = strings.ToLower(promotion)
+		}
+		rest = rest[:idx]
+	}
+
+	capture := strings.Contains(rest, "x")
+	rest = strings.ReplaceAll(rest, "x", "")
+	if len(rest) < 2 {
+		return nil, LastMove{}, NoTile, fmt.Errorf("chessimage: malformed move %q", san)
+	}
+
+	dest, err := TileFromAN(rest[len(rest)-2:])
+	if err != nil {
+		return nil, LastMove{}, NoTile, err
+	}
+	disambig := rest[:len(rest)-2]
+
+	var origin Tile
+	if strings.ToUpper(symbol) == "P" {
+		origin, err = findPawnOrigin(pos, symbol, dest, disambig, capture, whiteToMove)
+	} else {
+		fileHint, rankHint := parseDisambiguation(disambig)
+		origin, err = findOrigin(pos, symbol, dest, fileHint, rankHint)
+	}
+	if err != nil {
+		return nil, LastMove{}, NoTile, err
+	}
+
+	if next.PieceAt(dest) == NoPiece && capture && strings.ToUpper(symbol) == "P" {
+		// en passant: the captured pawn sits beside the origin, on the origin's rank
+		epTile := Tile(int(origin)/8*8 + int(dest)%8)
+		next.clearPiece(epTile)
+	}
+
+	next.clearPiece(origin)
+	if promotion != "" {
+		if err := next.setPiece(dest, promotion); err != nil {
+			return nil, LastMove{}, NoTile, err
+		}
+	} else if err := next.setPiece(dest, symbol); err != nil {
+		return nil, LastMove{}, NoTile, err
+	}
+
+	lastMove := LastMove{From: origin, To: dest}
+	return next, lastMove, checkTileFor(next, san, whiteToMove), nil
+}
+
+func checkTileFor(pos *Position, san string, whiteToMove bool) Tile {
+	if !strings.ContainsAny(san, "+#") {
+		return NoTile
+	}
+	// the side that just moved is whiteToMove; its opponent's king may be in check
+	kingSymbol := Piece("k")
+	if !whiteToMove {
+		kingSymbol = Piece("K")
+	}
+	for _, tile := range Squares(pos.Occupied()) {
+		if pos.PieceAt(tile) == kingSymbol {
+			return tile
+		}
+	}
+	return NoTile
+}
+
+func applyCastle(pos *Position, move string, whiteToMove bool) (LastMove, error) {
+	kingRow, kingSymbol, rookSymbol := 7, "K", "R"
+	if !whiteToMove {
+		kingRow, kingSymbol, rookSymbol = 0, "k", "r"
+	}
+	kingFrom := Tile(kingRow*8 + 4)
+	var kingTo, rookFrom, rookTo Tile
+	if move == "O-O" || move == "0-0" {
+		kingTo = Tile(kingRow*8 + 6)
+		rookFrom = Tile(kingRow*8 + 7)
+		rookTo = Tile(kingRow*8 + 5)
+	} else {
+		kingTo = Tile(kingRow*8 + 2)
+		rookFrom = Tile(kingRow*8 + 0)
+		rookTo = Tile(kingRow*8 + 3)
+	}
+	pos.clearPiece(kingFrom)
+	if err := pos.setPiece(kingTo, kingSymbol); err != nil {
+		return LastMove{}, err
+	}
+	pos.clearPiece(rookFrom)
+	if err := pos.setPiece(rookTo, rookSymbol); err != nil {
+		return LastMove{}, err
+	}
+	return LastMove{From: kingFrom, To: kingTo}, nil
+}
+
+func pieceSymbolFor(move string, whiteToMove bool) (symbol string, rest string) {
+	if len(move) > 0 && strings.ContainsRune("KQRBN", rune(move[0])) {
+		symbol = move[0:1]
+		rest = move[1:]
+	} else {
+		symbol = "P"
+		rest = move
+	}
+	if !whiteToMove {
+		symbol = strings.ToLower(symbol)
+	}
+	return symbol, rest
+}
+
+func parseDisambiguation(s string) (fileHint, rankHint int) {
+	fileHint, rankHint = -1, -1
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'h':
+			fileHint = int(c - 'a')
+		case c >= '1' && c <= '8':
+			rankHint = 7 - int(c-'1')
+		}
+	}
+	return fileHint, rankHint
+}
+
+func findPawnOrigin(pos *Position, symbol string, dest Tile, disambig string, capture bool, whiteToMove bool) (Tile, error) {
+	destRow, destFile := int(dest)/8, int(dest)%8
+	step := 1
+	startRow := 1
+	if whiteToMove {
+		step = -1
+		startRow = 6
+	}
+
+	if capture {
+		fileHint, _ := parseDisambiguation(disambig)
+		originRow := destRow - step
+		origin := Tile(originRow*8 + fileHint)
+		if pos.PieceAt(origin) == Piece(symbol) {
+			return origin, nil
+		}
+		return NoTile, fmt.Errorf("chessimage: no pawn can capture on %v", dest)
+	}
+
+	oneBack := Tile((destRow-step)*8 + destFile)
+	if pos.PieceAt(oneBack) == Piece(symbol) {
+		return oneBack, nil
+	}
+	twoBack := Tile((destRow-2*step)*8 + destFile)
+	if destRow-2*step == startRow && pos.PieceAt(twoBack) == Piece(symbol) {
+		return twoBack, nil
+	}
+	return NoTile, fmt.Errorf("chessimage: no pawn can reach %v", dest)
+}
+
+func findOrigin(pos *Position, symbol string, dest Tile, fileHint, rankHint int) (Tile, error) {
+	var candidates []Tile
+	for _, tile := range Squares(pos.Occupied()) {
+		if pos.PieceAt(tile) != Piece(symbol) {
+			continue
+		}
+		if fileHint >= 0 && tile.file() != fileHint {
+			continue
+		}
+		if rankHint >= 0 && tile.rank() != rankHint {
+			continue
+		}
+		if canReach(pos, symbol, tile, dest) && !movingExposesKing(pos, symbol, tile, dest) {
+			candidates = append(candidates, tile)
+		}
+	}
+	if len(candidates) == 0 {
+		return NoTile, fmt.Errorf("chessimage: no %s can reach %v", symbol, dest)
+	}
+	return candidates[0], nil
+}
+
+// movingExposesKing reports whether moving symbol from origin to dest would
+// leave the moving side's own king in check. findOrigin uses this to drop
+// pinned-piece candidates, since SAN only adds disambiguation when two
+// pieces could otherwise legally reach dest - a pinned piece doesn't count,
+// and without this check its pseudo-legal reach alone could shadow out the
+// correct, unpinned origin.
+func movingExposesKing(pos *Position, symbol string, origin, dest Tile) bool {
+	white := symbol == strings.ToUpper(symbol)
+	next := pos.clone()
+	next.clearPiece(dest)
+	next.clearPiece(origin)
+	if err := next.setPiece(dest, symbol); err != nil {
+		return false
+	}
+	king := kingTile(next, white)
+	if king == NoTile {
+		return false
+	}
+	return isAttacked(next, king, !white)
+}
+
+// kingTile returns the tile of the white (or black) king, or NoTile if it
+// can't be found.
+func kingTile(pos *Position, white bool) Tile {
+	symbol := "k"
+	if white {
+		symbol = "K"
+	}
+	for _, tile := range Squares(pos.Occupied()) {
+		if pos.PieceAt(tile) == Piece(symbol) {
+			return tile
+		}
+	}
+	return NoTile
+}
+
+// isAttacked reports whether any piece of the given color attacks target.
+func isAttacked(pos *Position, target Tile, byWhite bool) bool {
+	for _, origin := range Squares(pos.Occupied()) {
+		piece := pos.PieceAt(origin)
+		symbol := string(piece)
+		if (symbol == strings.ToUpper(symbol)) != byWhite {
+			continue
+		}
+		if attacksTile(pos, symbol, origin, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// attacksTile reports whether the piece symbol at origin attacks target,
+// including pawn captures, which canReach doesn't model since pawns can
+// only ever capture diagonally (never move there without one).
+func attacksTile(pos *Position, symbol string, origin, target Tile) bool {
+	if strings.ToUpper(symbol) != "P" {
+		return canReach(pos, symbol, origin, target)
+	}
+	dr := int(target)/8 - int(origin)/8
+	dc := int(target)%8 - int(origin)%8
+	direction := -1
+	if symbol == "p" {
+		direction = 1
+	}
+	return dr == direction && abs(dc) == 1
+}
+
+func canReach(pos *Position, symbol string, origin, dest Tile) bool {
+	or, oc := int(origin)/8, int(origin)%8
+	dr2, dc2 := int(dest)/8, int(dest)%8
+	dr, dc := dr2-or, dc2-oc
+	if dr == 0 && dc == 0 {
+		return false
+	}
+
+	switch strings.ToUpper(symbol) {
+	case "N":
+		adr, adc := abs(dr), abs(dc)
+		return (adr == 1 && adc == 2) || (adr == 2 && adc == 1)
+	case "B":
+		return abs(dr) == abs(dc) && pathClear(pos, origin, dest)
+	case "R":
+		return (dr == 0 || dc == 0) && pathClear(pos, origin, dest)
+	case "Q":
+		return (dr == 0 || dc == 0 || abs(dr) == abs(dc)) && pathClear(pos, origin, dest)
+	case "K":
+		return abs(dr) <= 1 && abs(dc) <= 1
+	}
+	return false
+}
+
+func pathClear(pos *Position, origin, dest Tile) bool {
+	or, oc := int(origin)/8, int(origin)%8
+	dr, dc := int(dest)/8-or, int(dest)%8-oc
+	steps := abs(dr)
+	if abs(dc) > steps {
+		steps = abs(dc)
+	}
+	stepR, stepC := sign(dr), sign(dc)
+	for i := 1; i < steps; i++ {
+		t := Tile((or+stepR*i)*8 + (oc + stepC*i))
+		if pos.PieceAt(t) != NoPiece {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	default:
+		return 0
+	}
+}
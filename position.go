@@ -0,0 +1,189 @@
+package chessimage
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// pieceKind indexes the twelve bitboards that make up a Position
+type pieceKind int
+
+const (
+	whitePawn pieceKind = iota
+	whiteKnight
+	whiteBishop
+	whiteRook
+	whiteQueen
+	whiteKing
+	blackPawn
+	blackKnight
+	blackBishop
+	blackRook
+	blackQueen
+	blackKing
+	pieceKindCount
+)
+
+var pieceKindSymbols = [pieceKindCount]string{
+	"P", "N", "B", "R", "Q", "K",
+	"p", "n", "b", "r", "q", "k",
+}
+
+// Piece identifies a chess piece by its symbol: uppercase for white,
+// lowercase for black, matching the keys of pieceNames.
+type Piece string
+
+// NoPiece represents the absence of a piece on a tile
+const NoPiece Piece = ""
+
+// Position is a bitboard-backed chess position: one uint64 per piece
+// type/color plus a combined occupancy board, giving O(1) piece lookup.
+type Position struct {
+	boards    [pieceKindCount]uint64
+	occupancy uint64
+}
+
+// PieceAt returns the piece occupying tile, or NoPiece if it is empty
+func (p *Position) PieceAt(tile Tile) Piece {
+	bit := uint64(1) << uint(tile)
+	if p.occupancy&bit == 0 {
+		return NoPiece
+	}
+	for kind := pieceKind(0); kind < pieceKindCount; kind++ {
+		if p.boards[kind]&bit != 0 {
+			return Piece(pieceKindSymbols[kind])
+		}
+	}
+	return NoPiece
+}
+
+// Occupied returns a bitboard with one bit set per occupied tile
+func (p *Position) Occupied() uint64 {
+	return p.occupancy
+}
+
+// Squares extracts the set tiles of a bitboard, in ascending order
+func Squares(bb uint64) []Tile {
+	var tiles []Tile
+	for bb != 0 {
+		idx := bits.TrailingZeros64(bb)
+		tiles = append(tiles, Tile(idx))
+		bb &= bb - 1
+	}
+	return tiles
+}
+
+// Diff returns the tiles whose occupant differs between p and other, useful
+// for redrawing only the squares that changed between successive positions.
+func (p *Position) Diff(other *Position) []Tile {
+	var diff []Tile
+	for tile := Tile(0); tile < 64; tile++ {
+		if p.PieceAt(tile) != other.PieceAt(tile) {
+			diff = append(diff, tile)
+		}
+	}
+	return diff
+}
+
+func (p *Position) clone() *Position {
+	cp := *p
+	return &cp
+}
+
+func (p *Position) kindOf(symbol string) (pieceKind, error) {
+	for kind, s := range pieceKindSymbols {
+		if s == symbol {
+			return pieceKind(kind), nil
+		}
+	}
+	return 0, fmt.Errorf("chessimage: unknown piece symbol %q", symbol)
+}
+
+func (p *Position) setPiece(tile Tile, symbol string) error {
+	kind, err := p.kindOf(symbol)
+	if err != nil {
+		return err
+	}
+	p.clearPiece(tile)
+	bit := uint64(1) << uint(tile)
+	p.boards[kind] |= bit
+	p.occupancy |= bit
+	return nil
+}
+
+func (p *Position) clearPiece(tile Tile) {
+	bit := ^(uint64(1) << uint(tile))
+	for kind := range p.boards {
+		p.boards[kind] &= bit
+	}
+	p.occupancy &= bit
+}
+
+// PositionFromFEN parses the piece-placement field of a FEN string into a Position
+func PositionFromFEN(fen string) (*Position, error) {
+	return decodeFEN(fen)
+}
+
+// FEN renders the position's piece placement back into FEN's piece-placement field
+func (p *Position) FEN() string {
+	var sb strings.Builder
+	for row := 0; row < 8; row++ {
+		empty := 0
+		for col := 0; col < 8; col++ {
+			piece := p.PieceAt(Tile(row*8 + col))
+			if piece == NoPiece {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteString(strconv.Itoa(empty))
+				empty = 0
+			}
+			sb.WriteString(string(piece))
+		}
+		if empty > 0 {
+			sb.WriteString(strconv.Itoa(empty))
+		}
+		if row < 7 {
+			sb.WriteString("/")
+		}
+	}
+	return sb.String()
+}
+
+// decodeFEN parses the piece-placement field of a FEN string (the part
+// before the first space) into a Position.
+func decodeFEN(fen string) (*Position, error) {
+	placement := strings.SplitN(fen, " ", 2)[0]
+	rows := strings.Split(placement, "/")
+	if len(rows) != 8 {
+		return nil, fmt.Errorf("chessimage: invalid FEN %q: expected 8 ranks, got %d", fen, len(rows))
+	}
+
+	p := &Position{}
+	for row, rowFEN := range rows {
+		col := 0
+		for _, c := range rowFEN {
+			switch {
+			case c >= '1' && c <= '8':
+				col += int(c - '0')
+			case strings.ContainsRune("pnbrqkPNBRQK", c):
+				if col >= 8 {
+					return nil, fmt.Errorf("chessimage: invalid FEN %q: rank %d overflows", fen, 8-row)
+				}
+				if err := p.setPiece(Tile(row*8+col), string(c)); err != nil {
+					return nil, err
+				}
+				col++
+			default:
+				return nil, fmt.Errorf("chessimage: invalid FEN %q: unexpected rune %q", fen, c)
+			}
+		}
+		if col != 8 {
+			return nil, fmt.Errorf("chessimage: invalid FEN %q: rank %d has %d files, want 8", fen, 8-row, col)
+		}
+	}
+	return p, nil
+}
@@ -4,9 +4,10 @@ import (
 	"embed"
 	_ "embed"
 	"image"
+	"image/color"
 	"image/png"
 	"io/fs"
-	"log"
+	"math"
 
 	"github.com/fogleman/gg"
 	"golang.org/x/image/draw"
@@ -40,19 +41,23 @@ const (
 	rankSymbolsReverse = "87654321"
 )
 
-var (
-	colorLight          = []int{240, 217, 181}
-	colorDark           = []int{181, 136, 99}
-	colorHighlightLight = []int{247, 193, 99}
-	colorHighlightDark  = []int{215, 149, 54}
-	colorCheck          = []int{255, 0, 0}
-)
-
 type drawSize struct {
 	gridSize               int
 	pieceSize, pieceOffset int
 }
 
+// marker is a single arbitrary square highlight added via Renderer.MarkSquare
+type marker struct {
+	tile  Tile
+	color color.Color
+}
+
+// arrow is a single annotation arrow added via Renderer.AddArrow
+type arrow struct {
+	from, to Tile
+	color    color.Color
+}
+
 // Options holds all possible rendering options for customization
 type Options struct {
 	FileSystem fs.FS
@@ -61,25 +66,31 @@ type Options struct {
 	BoardSize  int
 	PieceRatio float64
 	Inverted   bool
+	Theme      string
+	Palette    Palette
 }
 
 // Renderer is responsible for rendering the board, pieces, rank/file, and tile highlights
 type Renderer struct {
 	context   *gg.Context
-	board     board
+	position  *Position
 	drawSize  drawSize
+	palette   Palette
 	checkTile Tile
 	lastMove  *LastMove
+	markers   []marker
+	arrows    []arrow
+	game      *Game
 }
 
 // NewRendererFromFEN prepares a renderer for use with given FEN string
 func NewRendererFromFEN(fen string) (*Renderer, error) {
-	board, err := decodeFEN(fen)
+	position, err := decodeFEN(fen)
 	if err != nil {
 		return nil, err
 	}
 	return &Renderer{
-		board:     board,
+		position:  position,
 		checkTile: NoTile,
 	}, nil
 }
@@ -95,6 +106,21 @@ func (r *Renderer) SetLastMove(lastMove LastMove) {
 	r.lastMove = &lastMove
 }
 
+// MarkSquare highlights an arbitrary tile with a custom color, drawn as an
+// overlay beneath the pieces. c is used as given, so its alpha alone decides
+// how much of the square shows through; an opaque c covers it fully. Call
+// repeatedly to mark several squares at once.
+func (r *Renderer) MarkSquare(tile Tile, c color.Color) {
+	r.markers = append(r.markers, marker{tile: tile, color: c})
+}
+
+// AddArrow draws an arrow from one tile to another, for analysis/annotation
+// output. Arrows are drawn above the pieces. Call repeatedly to add several
+// arrows at once.
+func (r *Renderer) AddArrow(from, to Tile, c color.Color) {
+	r.arrows = append(r.arrows, arrow{from: from, to: to, color: c})
+}
+
 // Render the chess board with given items
 func (r *Renderer) Render(options Options) (image.Image, error) {
 	if options.BoardSize <= 0 {
@@ -107,107 +133,61 @@ func (r *Renderer) Render(options Options) (image.Image, error) {
 		options.Resizer = draw.CatmullRom
 	}
 	r.drawSize = calcDrawSize(options)
-	r.context = gg.NewContext(options.BoardSize, options.BoardSize)
-	r.drawBackground()
-	r.highlightCells(options)
-	r.drawCheckTile(options)
-	r.drawRankFile(options)
-	if err := r.drawBoard(options); err != nil {
+	r.palette = resolvePalette(options)
+
+	dst, err := r.renderTiles(options)
+	if err != nil {
 		return nil, err
 	}
-	return r.context.Image(), nil
-}
 
-func (r *Renderer) drawBackground() {
-	gridSize := r.drawSize.gridSize
-	for row := 0; row < 8; row++ {
-		for col := 0; col < 8; col++ {
-			r.context.DrawRectangle(float64(row*gridSize), float64(col*gridSize), float64(gridSize), float64(gridSize))
-			if (col+row)%2 == 0 {
-				r.context.SetRGB255(colorLight[0], colorLight[1], colorLight[2])
-			} else {
-				r.context.SetRGB255(colorDark[0], colorDark[1], colorDark[2])
-			}
-			r.context.Fill()
-		}
-	}
+	r.context = gg.NewContextForRGBA(dst)
+	r.drawRankFile(options)
+	r.drawArrows(options)
+	return r.context.Image(), nil
 }
 
-func (r *Renderer) highlightCells(o Options) {
-	if r.lastMove == nil {
-		return
-	}
-
-	var lastMoveFromRank, lastMoveToRank, lastMoveFromFile, lastMoveToFile int
-	if o.Inverted {
-		lastMoveFromRank = r.lastMove.From.rankInverted()
-		lastMoveFromFile = r.lastMove.From.fileInverted()
-		lastMoveToRank = r.lastMove.To.rankInverted()
-		lastMoveToFile = r.lastMove.To.fileInverted()
-	} else {
-		lastMoveFromRank = r.lastMove.From.rank()
-		lastMoveFromFile = r.lastMove.From.file()
-		lastMoveToRank = r.lastMove.To.rank()
-		lastMoveToFile = r.lastMove.To.file()
-	}
-
-	moveFromHighlight := colorHighlightLight
-	if lastMoveFromRank%2 != lastMoveFromFile%2 {
-		moveFromHighlight = colorHighlightDark
-	}
-	moveToHighlight := colorHighlightLight
-	if lastMoveToRank%2 != lastMoveToFile%2 {
-		moveToHighlight = colorHighlightDark
+// tileCoords returns the (file, rank) grid coordinates at which tile should
+// be drawn, accounting for board inversion. file is the column (x), rank is
+// the row (y).
+func tileCoords(tile Tile, inverted bool) (file, rank int) {
+	if inverted {
+		return tile.fileInverted(), tile.rankInverted()
 	}
-
-	gridSize := r.drawSize.gridSize
-	r.context.DrawRectangle(
-		float64(lastMoveFromFile*gridSize),
-		float64(lastMoveFromRank*gridSize),
-		float64(gridSize),
-		float64(gridSize))
-	r.context.SetRGB255(moveFromHighlight[0], moveFromHighlight[1], moveFromHighlight[2])
-	r.context.Fill()
-	r.context.DrawRectangle(
-		float64(lastMoveToFile*gridSize),
-		float64(lastMoveToRank*gridSize),
-		float64(gridSize), float64(gridSize))
-	r.context.SetRGB255(moveToHighlight[0], moveToHighlight[1], moveToHighlight[2])
-	r.context.Fill()
+	return tile.file(), tile.rank()
 }
 
-func (r *Renderer) drawCheckTile(o Options) {
-	if r.checkTile == NoTile {
-		return
-	}
-	var checkTileFile, checkTileRank int
-	if o.Inverted {
-		checkTileFile = r.checkTile.fileInverted()
-		checkTileRank = r.checkTile.rankInverted()
-	} else {
-		checkTileFile = r.checkTile.file()
-		checkTileRank = r.checkTile.rank()
-	}
+func (r *Renderer) drawArrows(o Options) {
 	gridSize := float64(r.drawSize.gridSize)
-	r.context.DrawRectangle(
-		float64(checkTileFile)*gridSize,
-		float64(checkTileRank)*gridSize,
-		gridSize,
-		gridSize,
-	)
-	r.context.SetRGB255(colorCheck[0], colorCheck[1], colorCheck[2])
+	for _, a := range r.arrows {
+		fromFile, fromRank := tileCoords(a.from, o.Inverted)
+		toFile, toRank := tileCoords(a.to, o.Inverted)
+		x1 := float64(fromFile)*gridSize + gridSize/2
+		y1 := float64(fromRank)*gridSize + gridSize/2
+		x2 := float64(toFile)*gridSize + gridSize/2
+		y2 := float64(toRank)*gridSize + gridSize/2
+		r.drawArrow(x1, y1, x2, y2, a.color, gridSize)
+	}
+}
+
+func (r *Renderer) drawArrow(x1, y1, x2, y2 float64, c color.Color, gridSize float64) {
+	lineWidth := gridSize * 0.12
+	headSize := gridSize * 0.3
+	angle := math.Atan2(y2-y1, x2-x1)
+
+	r.context.SetLineWidth(lineWidth)
+	r.context.SetColor(c)
+	r.context.DrawLine(x1, y1, x2-math.Cos(angle)*headSize, y2-math.Sin(angle)*headSize)
+	r.context.Stroke()
+
+	r.context.NewSubPath()
+	r.context.MoveTo(x2, y2)
+	r.context.LineTo(x2-headSize*math.Cos(angle-math.Pi/6), y2-headSize*math.Sin(angle-math.Pi/6))
+	r.context.LineTo(x2-headSize*math.Cos(angle+math.Pi/6), y2-headSize*math.Sin(angle+math.Pi/6))
+	r.context.ClosePath()
+	r.context.SetColor(c)
 	r.context.Fill()
 }
 
-func (r *Renderer) drawBoard(o Options) error {
-	for _, position := range r.board {
-		if err := r.drawPiece(position, o.FileSystem, o.AssetPath, o.Resizer, o.Inverted); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
 func (r *Renderer) drawRankFile(o Options) error {
 	var symbols string
 	r.context.SetFontFace(basicfont.Face7x13)
@@ -218,13 +198,11 @@ func (r *Renderer) drawRankFile(o Options) error {
 		symbols = fileSymbols
 	}
 	for i, symbol := range symbols {
-		var color []int
 		if i%2 == 0 {
-			color = colorLight
+			r.context.SetColor(r.palette.Light)
 		} else {
-			color = colorDark
+			r.context.SetColor(r.palette.Dark)
 		}
-		r.context.SetRGB255(color[0], color[1], color[2])
 		r.context.DrawString(string(symbol), float64(r.drawSize.gridSize*i+2), float64(o.BoardSize-3))
 	}
 
@@ -234,45 +212,17 @@ func (r *Renderer) drawRankFile(o Options) error {
 		symbols = rankSymbolsReverse
 	}
 	for i, symbol := range symbols {
-		var color []int
 		if i%2 == 0 {
-			color = colorLight
+			r.context.SetColor(r.palette.Light)
 		} else {
-			color = colorDark
+			r.context.SetColor(r.palette.Dark)
 		}
-		r.context.SetRGB255(color[0], color[1], color[2])
 		r.context.DrawString(string(symbol), float64(o.BoardSize-10), float64(r.drawSize.gridSize*i+12))
 	}
 
 	return nil
 }
 
-func (r *Renderer) drawPiece(piece position, fs fs.FS, assetPath string, resizer draw.Scaler, inverted bool) error {
-	// Todo move this to runtime cache function
-	png, err := loadPNG(fs, assetPath+pieceNames[string(piece.pieceSymbol)])
-	if err != nil {
-		return err
-	}
-	resized := resizeImage(png, r.drawSize, resizer)
-	if err != nil {
-		log.Fatal(err)
-	}
-	gridSize := r.drawSize.gridSize
-	pieceOffset := r.drawSize.pieceOffset
-
-	var pieceRank, pieceFile int
-	if inverted {
-		pieceRank = piece.tile.rankInverted()
-		pieceFile = piece.tile.fileInverted()
-	} else {
-		pieceRank = piece.tile.rank()
-		pieceFile = piece.tile.file()
-	}
-
-	r.context.DrawImage(resized, gridSize*(pieceRank)+pieceOffset, gridSize*(pieceFile)+pieceOffset)
-	return nil
-}
-
 func resizeImage(piece image.Image, drawSize drawSize, resizer draw.Scaler) *image.RGBA {
 	rect := image.Rect(0, 0, drawSize.pieceSize, drawSize.pieceSize)
 	dst := image.NewRGBA(rect)
@@ -290,12 +240,8 @@ func calcDrawSize(o Options) drawSize {
 	}
 }
 
-func loadPNG(fs fs.FS, assetPath string) (image.Image, error) {
-	if fs == nil {
-		fs = assets
-		assetPath = "assets/" + assetPath
-	}
-	file, err := fs.Open(assetPath)
+func loadPNG(fsys fs.FS, assetPath string) (image.Image, error) {
+	file, err := fsys.Open(assetPath)
 	if err != nil {
 		return nil, err
 	}
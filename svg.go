@@ -0,0 +1,199 @@
+package chessimage
+
+import (
+	"embed"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+//go:embed assets/svg/*
+var svgAssets embed.FS
+
+var svgPieceNames = map[string]string{
+	"b": "bd.svg",
+	"B": "bl.svg",
+	"k": "kd.svg",
+	"K": "kl.svg",
+	"n": "nd.svg",
+	"N": "nl.svg",
+	"p": "pd.svg",
+	"P": "pl.svg",
+	"q": "qd.svg",
+	"Q": "ql.svg",
+	"r": "rd.svg",
+	"R": "rl.svg",
+}
+
+// RenderSVG renders the chess board as an SVG document written to w. It reuses
+// the same board, highlight, check tile and last move state as Render, but
+// produces a scalable vector image instead of a rasterized PNG.
+func (r *Renderer) RenderSVG(w io.Writer, options Options) error {
+	if options.BoardSize <= 0 {
+		options.BoardSize = defaultBoardSize
+	}
+	if options.PieceRatio <= 0.0 {
+		options.PieceRatio = defaultPieceRatio
+	}
+	r.drawSize = calcDrawSize(options)
+	r.palette = resolvePalette(options)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		options.BoardSize, options.BoardSize, options.BoardSize, options.BoardSize)
+
+	r.writeSVGBackground(&sb)
+	r.writeSVGHighlights(&sb, options)
+	r.writeSVGCheckTile(&sb, options)
+	r.writeSVGRankFile(&sb, options)
+	if err := r.writeSVGBoard(&sb, options); err != nil {
+		return err
+	}
+
+	sb.WriteString("</svg>\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (r *Renderer) writeSVGBackground(sb *strings.Builder) {
+	gridSize := r.drawSize.gridSize
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			color := r.palette.Light
+			if (col+row)%2 != 0 {
+				color = r.palette.Dark
+			}
+			cr, cg, cb := rgb255(color)
+			fmt.Fprintf(sb, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"rgb(%d,%d,%d)\"/>\n",
+				row*gridSize, col*gridSize, gridSize, gridSize, cr, cg, cb)
+		}
+	}
+}
+
+func (r *Renderer) writeSVGHighlights(sb *strings.Builder, o Options) {
+	if r.lastMove == nil {
+		return
+	}
+
+	var lastMoveFromRank, lastMoveToRank, lastMoveFromFile, lastMoveToFile int
+	if o.Inverted {
+		lastMoveFromRank = r.lastMove.From.rankInverted()
+		lastMoveFromFile = r.lastMove.From.fileInverted()
+		lastMoveToRank = r.lastMove.To.rankInverted()
+		lastMoveToFile = r.lastMove.To.fileInverted()
+	} else {
+		lastMoveFromRank = r.lastMove.From.rank()
+		lastMoveFromFile = r.lastMove.From.file()
+		lastMoveToRank = r.lastMove.To.rank()
+		lastMoveToFile = r.lastMove.To.file()
+	}
+
+	moveFromHighlight := r.palette.HighlightLight
+	if lastMoveFromRank%2 != lastMoveFromFile%2 {
+		moveFromHighlight = r.palette.HighlightDark
+	}
+	moveToHighlight := r.palette.HighlightLight
+	if lastMoveToRank%2 != lastMoveToFile%2 {
+		moveToHighlight = r.palette.HighlightDark
+	}
+
+	gridSize := r.drawSize.gridSize
+	fr, fg, fb := rgb255(moveFromHighlight)
+	fmt.Fprintf(sb, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"rgb(%d,%d,%d)\"/>\n",
+		lastMoveFromFile*gridSize, lastMoveFromRank*gridSize, gridSize, gridSize, fr, fg, fb)
+	tr, tg, tb := rgb255(moveToHighlight)
+	fmt.Fprintf(sb, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"rgb(%d,%d,%d)\"/>\n",
+		lastMoveToFile*gridSize, lastMoveToRank*gridSize, gridSize, gridSize, tr, tg, tb)
+}
+
+func (r *Renderer) writeSVGCheckTile(sb *strings.Builder, o Options) {
+	if r.checkTile == NoTile {
+		return
+	}
+	var checkTileFile, checkTileRank int
+	if o.Inverted {
+		checkTileFile = r.checkTile.fileInverted()
+		checkTileRank = r.checkTile.rankInverted()
+	} else {
+		checkTileFile = r.checkTile.file()
+		checkTileRank = r.checkTile.rank()
+	}
+	gridSize := r.drawSize.gridSize
+	cr, cg, cb := rgb255(r.palette.Check)
+	fmt.Fprintf(sb, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"rgb(%d,%d,%d)\"/>\n",
+		checkTileFile*gridSize, checkTileRank*gridSize, gridSize, gridSize, cr, cg, cb)
+}
+
+func (r *Renderer) writeSVGRankFile(sb *strings.Builder, o Options) {
+	var symbols string
+	if o.Inverted {
+		symbols = fileSymbolsReverse
+	} else {
+		symbols = fileSymbols
+	}
+	for i, symbol := range symbols {
+		color := r.palette.Light
+		if i%2 != 0 {
+			color = r.palette.Dark
+		}
+		cr, cg, cb := rgb255(color)
+		fmt.Fprintf(sb, "<text x=\"%d\" y=\"%d\" font-size=\"10\" fill=\"rgb(%d,%d,%d)\">%s</text>\n",
+			r.drawSize.gridSize*i+2, o.BoardSize-3, cr, cg, cb, string(symbol))
+	}
+
+	if o.Inverted {
+		symbols = rankSymbols
+	} else {
+		symbols = rankSymbolsReverse
+	}
+	for i, symbol := range symbols {
+		color := r.palette.Light
+		if i%2 != 0 {
+			color = r.palette.Dark
+		}
+		cr, cg, cb := rgb255(color)
+		fmt.Fprintf(sb, "<text x=\"%d\" y=\"%d\" font-size=\"10\" fill=\"rgb(%d,%d,%d)\">%s</text>\n",
+			o.BoardSize-10, r.drawSize.gridSize*i+12, cr, cg, cb, string(symbol))
+	}
+}
+
+func (r *Renderer) writeSVGBoard(sb *strings.Builder, o Options) error {
+	for _, tile := range Squares(r.position.Occupied()) {
+		if err := r.writeSVGPiece(sb, tile, r.position.PieceAt(tile), o.FileSystem, o.AssetPath, o.Inverted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Renderer) writeSVGPiece(sb *strings.Builder, tile Tile, piece Piece, fsys fs.FS, assetPath string, inverted bool) error {
+	data, err := loadSVG(fsys, assetPath+svgPieceNames[string(piece)])
+	if err != nil {
+		return err
+	}
+	gridSize := r.drawSize.gridSize
+	pieceSize := r.drawSize.pieceSize
+	pieceOffset := r.drawSize.pieceOffset
+
+	pieceFile, pieceRank := tileCoords(tile, inverted)
+
+	fmt.Fprintf(sb, "<image x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" href=\"data:image/svg+xml;base64,%s\"/>\n",
+		gridSize*pieceFile+pieceOffset, gridSize*pieceRank+pieceOffset, pieceSize, pieceSize,
+		base64.StdEncoding.EncodeToString(data))
+	return nil
+}
+
+func loadSVG(fsys fs.FS, assetPath string) ([]byte, error) {
+	if fsys == nil {
+		fsys = svgAssets
+		assetPath = "assets/svg/" + assetPath
+	}
+	file, err := fsys.Open(assetPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
@@ -0,0 +1,72 @@
+package chessimage
+
+import (
+	"image/color"
+	"io/fs"
+)
+
+// defaultThemeName is used whenever Options.Theme is left blank
+const defaultThemeName = "cburnett"
+
+// pieceTheme is a registered set of piece images rooted at path within fsys
+type pieceTheme struct {
+	fs   fs.FS
+	path string
+}
+
+var themes = map[string]pieceTheme{
+	defaultThemeName: {fs: assets, path: "assets/"},
+}
+
+// RegisterTheme registers a named piece set backed by fsys, rooted at path,
+// making it selectable per-render via Options.Theme. Use this to ship custom
+// piece sets bundled with an embed.FS.
+func RegisterTheme(name string, fsys fs.FS, path string) {
+	themes[name] = pieceTheme{fs: fsys, path: path}
+}
+
+func resolveTheme(name string) pieceTheme {
+	if name == "" {
+		name = defaultThemeName
+	}
+	if t, ok := themes[name]; ok {
+		return t
+	}
+	return themes[defaultThemeName]
+}
+
+// Palette describes the colors used to render the board squares, last-move
+// highlights and check tile. Marker is not read by the renderers: each
+// square marker is drawn in the color passed to Renderer.MarkSquare.
+type Palette struct {
+	Light          color.Color
+	Dark           color.Color
+	HighlightLight color.Color
+	HighlightDark  color.Color
+	Check          color.Color
+	Marker         color.Color
+}
+
+// DefaultPalette is used whenever Options.Palette is left as the zero value
+var DefaultPalette = Palette{
+	Light:          color.RGBA{R: 240, G: 217, B: 181, A: 255},
+	Dark:           color.RGBA{R: 181, G: 136, B: 99, A: 255},
+	HighlightLight: color.RGBA{R: 247, G: 193, B: 99, A: 255},
+	HighlightDark:  color.RGBA{R: 215, G: 149, B: 54, A: 255},
+	Check:          color.RGBA{R: 255, G: 0, B: 0, A: 255},
+	Marker:         color.RGBA{R: 0, G: 0, B: 0, A: 128},
+}
+
+func resolvePalette(o Options) Palette {
+	if o.Palette == (Palette{}) {
+		return DefaultPalette
+	}
+	return o.Palette
+}
+
+// rgb255 extracts 8-bit RGB components from c, for backends (such as the SVG
+// writer) that need plain numbers rather than a color.Color.
+func rgb255(c color.Color) (r, g, b int) {
+	cr, cg, cb, _ := c.RGBA()
+	return int(cr >> 8), int(cg >> 8), int(cb >> 8)
+}
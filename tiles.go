@@ -0,0 +1,259 @@
+package chessimage
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	stddraw "image/draw"
+	"runtime"
+	"sync"
+
+	"github.com/fogleman/gg"
+)
+
+// tileResult carries one worker's composed tile back to renderTiles, along
+// with the grid position it belongs at and any error it hit.
+type tileResult struct {
+	tile  Tile
+	image *image.RGBA
+	err   error
+}
+
+// renderTiles composes the 64 board squares (background, highlight, check
+// tile, markers and piece) concurrently, each worker producing a small
+// tile-sized image.RGBA that is written into its disjoint sub-rectangle of
+// the destination board image. This replaces the old sequential
+// drawBackground/highlightCells/drawCheckTile/drawBoard passes with a single
+// per-tile pipeline, letting identical tiles (e.g. an empty dark square) be
+// reused across renders via globalTileCache instead of repainted.
+func (r *Renderer) renderTiles(o Options) (*image.RGBA, error) {
+	gridSize := r.drawSize.gridSize
+	dst := image.NewRGBA(image.Rect(0, 0, o.BoardSize, o.BoardSize))
+
+	numWorkers := runtime.NumCPU()
+	if numWorkers > 64 {
+		numWorkers = 64
+	}
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+
+	jobs := make(chan Tile, 64)
+	results := make(chan tileResult, 64)
+
+	var workers sync.WaitGroup
+	workers.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go func() {
+			defer workers.Done()
+			for tile := range jobs {
+				img, err := r.renderTile(tile, o)
+				results <- tileResult{tile: tile, image: img, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for tile := Tile(0); tile < 64; tile++ {
+			jobs <- tile
+		}
+		close(jobs)
+	}()
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	var firstErr error
+	for res := range results {
+		if res.err != nil {
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			continue
+		}
+		file, rank := tileCoords(res.tile, o.Inverted)
+		rect := image.Rect(file*gridSize, rank*gridSize, (file+1)*gridSize, (rank+1)*gridSize)
+		stddraw.Draw(dst, rect, res.image, image.Point{}, stddraw.Over)
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return dst, nil
+}
+
+// renderTile composes a single board square into a gridSize x gridSize
+// image, consulting globalTileCache first since a tile's appearance is fully
+// determined by its piece and highlight state.
+func (r *Renderer) renderTile(tile Tile, o Options) (*image.RGBA, error) {
+	piece := r.position.PieceAt(tile)
+	key := r.tileCacheKey(tile, piece, o)
+	if cached, ok := globalTileCache.get(key); ok {
+		return cached, nil
+	}
+
+	gridSize := r.drawSize.gridSize
+	img := image.NewRGBA(image.Rect(0, 0, gridSize, gridSize))
+	ctx := gg.NewContextForRGBA(img)
+	r.paintTileBackground(ctx, tile, o)
+	if piece != NoPiece {
+		if err := r.paintTilePiece(ctx, piece, o); err != nil {
+			return nil, err
+		}
+	}
+
+	globalTileCache.put(key, img)
+	return img, nil
+}
+
+// tileCacheKey hashes everything that affects a tile's appearance: the
+// piece occupying it, its background/highlight/check/marker state, the
+// effective palette, the piece theme (or the explicit Options.FileSystem and
+// AssetPath it was loaded from), and the tile's pixel size.
+// globalTileCache is shared across renderers, so the palette and piece size
+// must be part of the key - otherwise a tile composed for one
+// Options.Palette or Options.PieceRatio could be handed back to a render
+// using different values.
+func (r *Renderer) tileCacheKey(tile Tile, piece Piece, o Options) string {
+	theme := o.Theme
+	if theme == "" {
+		theme = defaultThemeName
+	}
+	var fsID string
+	if o.FileSystem != nil {
+		fsID = fmt.Sprintf("%p", o.FileSystem)
+	}
+
+	h := sha1.New()
+	fmt.Fprintf(h, "%s|%s|%q|%s|%s|%s|%d|%d|%d", theme, fsID, o.AssetPath, piece, r.tileState(tile, o), r.paletteKey(),
+		r.drawSize.gridSize, r.drawSize.pieceSize, r.drawSize.pieceOffset)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// paletteKey renders the structural palette colors (everything but the
+// per-marker color, which tileState already mixes in) as a short string.
+func (r *Renderer) paletteKey() string {
+	return fmt.Sprintf("%s,%s,%s,%s,%s",
+		colorKey(r.palette.Light), colorKey(r.palette.Dark),
+		colorKey(r.palette.HighlightLight), colorKey(r.palette.HighlightDark),
+		colorKey(r.palette.Check))
+}
+
+func colorKey(c color.Color) string {
+	cr, cg, cb, ca := c.RGBA()
+	return fmt.Sprintf("%d:%d:%d:%d", cr, cg, cb, ca)
+}
+
+// tileState summarizes the highlight/check/marker state of tile as a short
+// string, so that two tiles with identical state hash identically.
+func (r *Renderer) tileState(tile Tile, o Options) string {
+	file, rank := tileCoords(tile, o.Inverted)
+	state := "light"
+	if (file+rank)%2 != 0 {
+		state = "dark"
+	}
+
+	if r.lastMove != nil {
+		switch tile {
+		case r.lastMove.From:
+			state += ":from"
+		case r.lastMove.To:
+			state += ":to"
+		}
+	}
+	if tile == r.checkTile {
+		state += ":check"
+	}
+	for _, m := range r.markers {
+		if m.tile == tile {
+			mr, mg, mb, ma := m.color.RGBA()
+			state += fmt.Sprintf(":marker(%d,%d,%d,%d)", mr, mg, mb, ma)
+		}
+	}
+	return state
+}
+
+// paintTileBackground fills a tile-local context with its checkerboard
+// color, then layers the last-move highlight, check tile and any markers
+// that apply to it.
+func (r *Renderer) paintTileBackground(ctx *gg.Context, tile Tile, o Options) {
+	gridSize := float64(r.drawSize.gridSize)
+	file, rank := tileCoords(tile, o.Inverted)
+	dark := (file+rank)%2 != 0
+
+	bg := r.palette.Light
+	if dark {
+		bg = r.palette.Dark
+	}
+	ctx.DrawRectangle(0, 0, gridSize, gridSize)
+	ctx.SetColor(bg)
+	ctx.Fill()
+
+	if r.lastMove != nil && (tile == r.lastMove.From || tile == r.lastMove.To) {
+		highlight := r.palette.HighlightLight
+		if dark {
+			highlight = r.palette.HighlightDark
+		}
+		ctx.DrawRectangle(0, 0, gridSize, gridSize)
+		ctx.SetColor(highlight)
+		ctx.Fill()
+	}
+
+	if tile == r.checkTile {
+		ctx.DrawRectangle(0, 0, gridSize, gridSize)
+		ctx.SetColor(r.palette.Check)
+		ctx.Fill()
+	}
+
+	for _, m := range r.markers {
+		if m.tile == tile {
+			ctx.DrawRectangle(0, 0, gridSize, gridSize)
+			ctx.SetColor(m.color)
+			ctx.Fill()
+		}
+	}
+}
+
+// paintTilePiece draws piece's resized image, loaded via globalPieceCache,
+// onto a tile-local context.
+func (r *Renderer) paintTilePiece(ctx *gg.Context, piece Piece, o Options) error {
+	img, err := r.loadPieceImage(piece, o)
+	if err != nil {
+		return err
+	}
+	offset := r.drawSize.pieceOffset
+	ctx.DrawImage(img, offset, offset)
+	return nil
+}
+
+// loadPieceImage resolves piece's theme asset, decoding and resizing it on
+// a cache miss.
+func (r *Renderer) loadPieceImage(piece Piece, o Options) (*image.RGBA, error) {
+	theme := o.Theme
+	var fsID string
+	if o.FileSystem != nil {
+		fsID = fmt.Sprintf("%p", o.FileSystem)
+	} else if theme == "" {
+		theme = defaultThemeName
+	}
+	key := pieceCacheKey{theme: theme, fsID: fsID, assetPath: o.AssetPath, symbol: string(piece), pieceSize: r.drawSize.pieceSize}
+
+	if resized, ok := globalPieceCache.get(key); ok {
+		return resized, nil
+	}
+
+	fsys, assetPath := o.FileSystem, o.AssetPath
+	if fsys == nil {
+		t := resolveTheme(o.Theme)
+		fsys, assetPath = t.fs, t.path+o.AssetPath
+	}
+	png, err := loadPNG(fsys, assetPath+pieceNames[string(piece)])
+	if err != nil {
+		return nil, err
+	}
+	resized := resizeImage(png, r.drawSize, o.Resizer)
+	globalPieceCache.put(key, resized)
+	return resized, nil
+}
@@ -25,20 +25,13 @@ func tileFromRankFile(rank int, file int) Tile {
 	return Tile(file*8 + rank)
 }
 
-type position struct {
-	tile        Tile
-	pieceSymbol string
-}
-
-type board []position
-
-//LastMove represents two tiles that indicate a piece was moved
+// LastMove represents two tiles that indicate a piece was moved
 type LastMove struct {
 	From Tile
 	To   Tile
 }
 
-//TileFromAN will attempt to get a tile by its algebraic notation (ie: "e5")
+// TileFromAN will attempt to get a tile by its algebraic notation (ie: "e5")
 func TileFromAN(an string) (Tile, error) {
 	tile, ok := tileMap[an]
 	if !ok {